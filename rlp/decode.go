@@ -0,0 +1,629 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rlp
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/MatrixAINetwork/go-matrix/rlp/internal/rlpstruct"
+)
+
+// Decoder is implemented by types that require custom RLP decoding rules or
+// need to decode into private fields.
+type Decoder interface {
+	DecodeRLP(*Stream) error
+}
+
+var decoderInterface = reflect.TypeOf(new(Decoder)).Elem()
+
+// Kind represents the kind of value contained in an RLP stream item.
+type Kind int
+
+const (
+	Byte Kind = iota
+	String
+	List
+)
+
+var (
+	ErrExpectedString   = errors.New("rlp: expected String or Byte")
+	ErrExpectedList     = errors.New("rlp: expected List")
+	ErrCanonInt         = errors.New("rlp: non-canonical integer format")
+	ErrValueTooLarge    = errors.New("rlp: value size exceeds available input length")
+	ErrMoreThanOneValue = errors.New("rlp: input contains more than one value")
+
+	errNotInList     = errors.New("rlp: call of ListEnd outside of any list")
+	errNotAtEOL      = errors.New("rlp: call of ListEnd not positioned at end of list")
+	errUintOverflow  = errors.New("rlp: uint overflow")
+	errNoPointer     = errors.New("rlp: interface given to Decode must be a pointer")
+	errDecodeIntoNil = errors.New("rlp: pointer given to Decode must not be nil")
+
+	// EOL is returned by stream operations when reading has reached
+	// the end of the current list.
+	EOL = errors.New("rlp: end of list")
+)
+
+// Stream reads RLP-encoded data from an in-memory buffer and exposes the
+// low-level primitives (Uint64, Bytes, List, ...) that rlpgen-generated
+// DecodeRLP methods use directly, as well as the reflection-based decoder
+// dispatch in makeDecoder.
+type Stream struct {
+	data  []byte
+	pos   int
+	stack []int // end offsets of enclosing lists, outermost first
+}
+
+// NewStream creates a Stream that reads from data.
+func NewStream(data []byte) *Stream {
+	return &Stream{data: data}
+}
+
+// DecodeBytes parses RLP data from b into val, which must be a non-nil
+// pointer. It is an error if b contains additional data after val.
+func DecodeBytes(b []byte, val interface{}) error {
+	s := NewStream(b)
+	if err := s.Decode(val); err != nil {
+		return err
+	}
+	if s.pos != len(b) {
+		return ErrMoreThanOneValue
+	}
+	return nil
+}
+
+// Decode reads one RLP value from the stream into val, which must be a
+// non-nil pointer.
+func (s *Stream) Decode(val interface{}) error {
+	rval := reflect.ValueOf(val)
+	if rval.Kind() != reflect.Ptr {
+		return errNoPointer
+	}
+	if rval.IsNil() {
+		return errDecodeIntoNil
+	}
+	ti := cachedTypeInfo(rval.Elem().Type(), rlpstruct.Tags{})
+	if ti.decoderErr != nil {
+		return ti.decoderErr
+	}
+	return ti.decoder(s, rval.Elem())
+}
+
+// listEnd returns the offset at which the innermost enclosing list (or the
+// whole input, if not inside a list) ends.
+func (s *Stream) listEnd() int {
+	if len(s.stack) == 0 {
+		return len(s.data)
+	}
+	return s.stack[len(s.stack)-1]
+}
+
+// readHeader inspects the item at the stream's current position without
+// consuming it, returning its kind and the bounds of its content within
+// s.data. For a single-byte value, contentStart equals the current position
+// and contentEnd is contentStart+1, so advancing to contentEnd always moves
+// past the item regardless of kind.
+func (s *Stream) readHeader() (kind Kind, contentStart, contentEnd int, err error) {
+	if s.pos >= s.listEnd() {
+		return 0, 0, 0, EOL
+	}
+	b := s.data[s.pos]
+	switch {
+	case b < 0x80:
+		return Byte, s.pos, s.pos + 1, nil
+	case b < 0xB8:
+		start := s.pos + 1
+		end := start + int(b-0x80)
+		if end > s.listEnd() {
+			return 0, 0, 0, ErrValueTooLarge
+		}
+		return String, start, end, nil
+	case b < 0xC0:
+		lenlen := int(b - 0xB7)
+		lstart, lend := s.pos+1, s.pos+1+lenlen
+		if lend > s.listEnd() {
+			return 0, 0, 0, ErrValueTooLarge
+		}
+		size, err := decodeLength(s.data[lstart:lend])
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		start := lend
+		end := start + size
+		if end > s.listEnd() {
+			return 0, 0, 0, ErrValueTooLarge
+		}
+		return String, start, end, nil
+	case b < 0xF8:
+		start := s.pos + 1
+		end := start + int(b-0xC0)
+		if end > s.listEnd() {
+			return 0, 0, 0, ErrValueTooLarge
+		}
+		return List, start, end, nil
+	default:
+		lenlen := int(b - 0xF7)
+		lstart, lend := s.pos+1, s.pos+1+lenlen
+		if lend > s.listEnd() {
+			return 0, 0, 0, ErrValueTooLarge
+		}
+		size, err := decodeLength(s.data[lstart:lend])
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		start := lend
+		end := start + size
+		if end > s.listEnd() {
+			return 0, 0, 0, ErrValueTooLarge
+		}
+		return List, start, end, nil
+	}
+}
+
+func decodeLength(b []byte) (int, error) {
+	if len(b) > 0 && b[0] == 0 {
+		return 0, ErrCanonInt
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	if n > uint64(1)<<31 {
+		return 0, ErrValueTooLarge
+	}
+	return int(n), nil
+}
+
+func (s *Stream) advance(contentEnd int) {
+	s.pos = contentEnd
+}
+
+// Kind returns the kind and size of the next value in the stream, without
+// consuming it.
+func (s *Stream) Kind() (Kind, uint64, error) {
+	kind, start, end, err := s.readHeader()
+	if err != nil {
+		return 0, 0, err
+	}
+	return kind, uint64(end - start), nil
+}
+
+// List starts decoding an RLP list, returning its content size.
+func (s *Stream) List() (uint64, error) {
+	kind, start, end, err := s.readHeader()
+	if err != nil {
+		return 0, err
+	}
+	if kind != List {
+		return 0, ErrExpectedList
+	}
+	s.stack = append(s.stack, end)
+	s.pos = start
+	return uint64(end - start), nil
+}
+
+// ListEnd closes a list opened with List. It is an error to call ListEnd
+// before all of the list's elements have been read.
+func (s *Stream) ListEnd() error {
+	if len(s.stack) == 0 {
+		return errNotInList
+	}
+	end := s.stack[len(s.stack)-1]
+	if s.pos != end {
+		return errNotAtEOL
+	}
+	s.stack = s.stack[:len(s.stack)-1]
+	return nil
+}
+
+// MoreDataInList reports whether the current list has more elements to read.
+// rlpgen-generated decoders use this to implement optional trailing fields.
+func (s *Stream) MoreDataInList() bool {
+	return s.pos < s.listEnd()
+}
+
+// Bool decodes a boolean value.
+func (s *Stream) Bool() (bool, error) {
+	v, err := s.uintn(8)
+	if err != nil {
+		return false, err
+	}
+	switch v {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("rlp: invalid boolean value: %d", v)
+	}
+}
+
+func (s *Stream) uintn(bits int) (uint64, error) {
+	kind, start, end, err := s.readHeader()
+	if err != nil {
+		return 0, err
+	}
+	if kind == List {
+		return 0, ErrExpectedString
+	}
+	if end-start > 8 {
+		return 0, errUintOverflow
+	}
+	var v uint64
+	for _, b := range s.data[start:end] {
+		v = v<<8 | uint64(b)
+	}
+	if bits < 64 && v>>uint(bits) != 0 {
+		return 0, errUintOverflow
+	}
+	s.advance(end)
+	return v, nil
+}
+
+// Uint8 decodes an 8-bit unsigned integer.
+func (s *Stream) Uint8() (uint8, error) {
+	v, err := s.uintn(8)
+	return uint8(v), err
+}
+
+// Uint16 decodes a 16-bit unsigned integer.
+func (s *Stream) Uint16() (uint16, error) {
+	v, err := s.uintn(16)
+	return uint16(v), err
+}
+
+// Uint32 decodes a 32-bit unsigned integer.
+func (s *Stream) Uint32() (uint32, error) {
+	v, err := s.uintn(32)
+	return uint32(v), err
+}
+
+// Uint64 decodes a 64-bit unsigned integer.
+func (s *Stream) Uint64() (uint64, error) {
+	return s.uintn(64)
+}
+
+// BigInt decodes an arbitrary-precision unsigned integer.
+func (s *Stream) BigInt() (*big.Int, error) {
+	kind, start, end, err := s.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	if kind == List {
+		return nil, ErrExpectedString
+	}
+	b := s.data[start:end]
+	if len(b) > 0 && b[0] == 0 {
+		return nil, ErrCanonInt
+	}
+	s.advance(end)
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Bytes decodes an RLP string into a newly allocated byte slice.
+func (s *Stream) Bytes() ([]byte, error) {
+	kind, start, end, err := s.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	if kind == List {
+		return nil, ErrExpectedString
+	}
+	b := make([]byte, end-start)
+	copy(b, s.data[start:end])
+	s.advance(end)
+	return b, nil
+}
+
+// ReadBytes decodes an RLP string into b, which must have exactly the right
+// length. rlpgen-generated decoders use this for fixed-size byte array
+// fields.
+func (s *Stream) ReadBytes(b []byte) error {
+	kind, start, end, err := s.readHeader()
+	if err != nil {
+		return err
+	}
+	if kind == List {
+		return ErrExpectedString
+	}
+	if end-start != len(b) {
+		return fmt.Errorf("rlp: value size does not match fixed-size buffer of length %d", len(b))
+	}
+	copy(b, s.data[start:end])
+	s.advance(end)
+	return nil
+}
+
+// makeDecoder resolves the decoder func for typ, honoring the rlp struct
+// tags that affect decoding (currently "optional", consulted by
+// makeStructDecoder, and "nil"/"nilString"/"nilList", consulted by
+// makePtrDecoder).
+func makeDecoder(typ reflect.Type, tags rlpstruct.Tags) (decoder, error) {
+	kind := typ.Kind()
+	switch {
+	case typ == bigIntPtrType:
+		return decodeBigInt, nil
+	case kind == reflect.Ptr && typ.Implements(decoderInterface):
+		return decodeDecoder, nil
+	case kind != reflect.Ptr && reflect.PtrTo(typ).Implements(decoderInterface):
+		return decodeDecoderNoPtr, nil
+	case kind == reflect.Bool:
+		return decodeBool, nil
+	case isUint(kind):
+		return decodeUint, nil
+	case kind == reflect.String:
+		return decodeString, nil
+	case kind == reflect.Slice || kind == reflect.Array:
+		return makeListDecoder(typ)
+	case kind == reflect.Struct:
+		return makeStructDecoder(typ)
+	case kind == reflect.Ptr:
+		return makePtrDecoder(typ, tags)
+	default:
+		return nil, fmt.Errorf("rlp: type %v is not RLP-serializable", typ)
+	}
+}
+
+func decodeBool(s *Stream, val reflect.Value) error {
+	b, err := s.Bool()
+	if err != nil {
+		return err
+	}
+	val.SetBool(b)
+	return nil
+}
+
+func decodeUint(s *Stream, val reflect.Value) error {
+	num, err := s.uintn(val.Type().Bits())
+	if err != nil {
+		return err
+	}
+	val.SetUint(num)
+	return nil
+}
+
+func decodeString(s *Stream, val reflect.Value) error {
+	b, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	val.SetString(string(b))
+	return nil
+}
+
+func decodeBigInt(s *Stream, val reflect.Value) error {
+	i, err := s.BigInt()
+	if err != nil {
+		return err
+	}
+	val.Set(reflect.ValueOf(i))
+	return nil
+}
+
+func decodeDecoder(s *Stream, val reflect.Value) error {
+	if val.IsNil() {
+		val.Set(reflect.New(val.Type().Elem()))
+	}
+	return val.Interface().(Decoder).DecodeRLP(s)
+}
+
+func decodeDecoderNoPtr(s *Stream, val reflect.Value) error {
+	return val.Addr().Interface().(Decoder).DecodeRLP(s)
+}
+
+// makeListDecoder returns the decoder for a slice or array type. Byte slices
+// and byte arrays are decoded from a single RLP string; everything else is
+// decoded from a list using the element decoder.
+func makeListDecoder(typ reflect.Type) (decoder, error) {
+	etype := typ.Elem()
+	if etype.Kind() == reflect.Uint8 {
+		if typ.Kind() == reflect.Array {
+			return decodeByteArray, nil
+		}
+		return decodeByteSlice, nil
+	}
+	etypeinfo := theTC.infoWhileGenerating(etype, rlpstruct.Tags{})
+	if etypeinfo.decoderErr != nil {
+		return nil, etypeinfo.decoderErr
+	}
+	if typ.Kind() == reflect.Array {
+		dec := func(s *Stream, val reflect.Value) error {
+			return decodeListArray(s, val, etypeinfo.decoder)
+		}
+		return dec, nil
+	}
+	dec := func(s *Stream, val reflect.Value) error {
+		return decodeListSlice(s, val, etypeinfo.decoder)
+	}
+	return dec, nil
+}
+
+func decodeListSlice(s *Stream, val reflect.Value, elemdec decoder) error {
+	size, err := s.List()
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		val.Set(reflect.MakeSlice(val.Type(), 0, 0))
+		return s.ListEnd()
+	}
+	i := 0
+	for ; ; i++ {
+		if i >= val.Cap() {
+			newcap := val.Cap() + val.Cap()/2
+			if newcap < 4 {
+				newcap = 4
+			}
+			newv := reflect.MakeSlice(val.Type(), val.Len(), newcap)
+			reflect.Copy(newv, val)
+			val.Set(newv)
+		}
+		if i >= val.Len() {
+			val.SetLen(i + 1)
+		}
+		if err := elemdec(s, val.Index(i)); err == EOL {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if i < val.Len() {
+		val.SetLen(i)
+	}
+	return s.ListEnd()
+}
+
+func decodeListArray(s *Stream, val reflect.Value, elemdec decoder) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	vlen := val.Len()
+	i := 0
+	for ; i < vlen; i++ {
+		if err := elemdec(s, val.Index(i)); err == EOL {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if i < vlen {
+		return fmt.Errorf("rlp: input list has too few elements for array of type %v", val.Type())
+	}
+	return s.ListEnd()
+}
+
+func decodeByteSlice(s *Stream, val reflect.Value) error {
+	b, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	val.SetBytes(b)
+	return nil
+}
+
+func decodeByteArray(s *Stream, val reflect.Value) error {
+	if !val.CanAddr() {
+		cp := reflect.New(val.Type()).Elem()
+		if err := decodeByteArray(s, cp); err != nil {
+			return err
+		}
+		val.Set(cp)
+		return nil
+	}
+	slice := val.Slice(0, val.Len()).Bytes()
+	return s.ReadBytes(slice)
+}
+
+// makePtrDecoder returns the decoder for a pointer type. When the field
+// carries a "nil"/"nilString"/"nilList" tag, a missing value (EOL) or an
+// empty value of the kind matching ts.NilKind leaves the field nil instead
+// of calling the elem decoder; an empty value of the *wrong* kind (e.g. an
+// empty list where ts.NilKind wants an empty string) is rejected with a
+// clear error rather than silently accepted.
+func makePtrDecoder(typ reflect.Type, ts rlpstruct.Tags) (decoder, error) {
+	etype := typ.Elem()
+	etypeinfo := theTC.infoWhileGenerating(etype, rlpstruct.Tags{})
+	if etypeinfo.decoderErr != nil {
+		return nil, etypeinfo.decoderErr
+	}
+	dec := func(s *Stream, val reflect.Value) (err error) {
+		if ts.NilOK {
+			kind, size, err := s.Kind()
+			if err == EOL {
+				val.Set(reflect.Zero(typ))
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if size == 0 {
+				if !isNilKind(kind, ts.NilKind) {
+					return fmt.Errorf("rlp: wrong kind of empty value for %v", typ)
+				}
+				// Consume the empty value so the stream advances past it.
+				if kind == List {
+					if _, err := s.List(); err != nil {
+						return err
+					}
+					if err := s.ListEnd(); err != nil {
+						return err
+					}
+				} else if _, err := s.Bytes(); err != nil {
+					return err
+				}
+				val.Set(reflect.Zero(typ))
+				return nil
+			}
+		}
+		newval := reflect.New(etype)
+		if err = etypeinfo.decoder(s, newval.Elem()); err == nil {
+			val.Set(newval)
+		}
+		return err
+	}
+	return dec, nil
+}
+
+// isNilKind reports whether an empty value of kind k matches the expected
+// empty representation nk (NilKindString for an empty string, NilKindList
+// for an empty list).
+func isNilKind(k Kind, nk rlpstruct.NilKind) bool {
+	switch k {
+	case Byte, String:
+		return nk == rlpstruct.NilKindString
+	case List:
+		return nk == rlpstruct.NilKindList
+	default:
+		return false
+	}
+}
+
+// makeStructDecoder returns the decoder for a struct type. Fields tagged
+// "optional" may be missing from the trailing end of the input list; when
+// the stream runs out (EOL) on an optional field, that field and all
+// following fields are left at their zero value.
+func makeStructDecoder(typ reflect.Type) (decoder, error) {
+	fields, err := structFields(typ)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if f.info.decoderErr != nil {
+			return nil, fmt.Errorf("rlp: field %q of %v: %v", typ.Field(f.index).Name, typ, f.info.decoderErr)
+		}
+	}
+	dec := func(s *Stream, val reflect.Value) error {
+		if _, err := s.List(); err != nil {
+			return err
+		}
+		for _, f := range fields {
+			err := f.info.decoder(s, val.Field(f.index))
+			if err == EOL {
+				if f.optional {
+					break
+				}
+				return fmt.Errorf("rlp: too few elements for %v", typ)
+			} else if err != nil {
+				return err
+			}
+		}
+		return s.ListEnd()
+	}
+	return dec, nil
+}
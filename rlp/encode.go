@@ -0,0 +1,438 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rlp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/rlp/internal/rlpstruct"
+)
+
+// Encoder is implemented by types that require custom RLP encoding rules or
+// need to encode private fields.
+type Encoder interface {
+	// EncodeRLP should write the RLP encoding of its receiver to w.
+	// If the implementation is a pointer method, it may also be
+	// called for nil pointers.
+	EncodeRLP(io.Writer) error
+}
+
+var encoderInterface = reflect.TypeOf(new(Encoder)).Elem()
+
+// bigIntPtrType is used to detect *big.Int fields, which get a dedicated
+// writer/decoder instead of going through the generic pointer and struct
+// dispatch.
+var bigIntPtrType = reflect.TypeOf((*big.Int)(nil))
+
+// EncodeToBytes returns the RLP encoding of val.
+func EncodeToBytes(val interface{}) ([]byte, error) {
+	buf := encbufPool.Get().(*encbuf)
+	defer encbufPool.Put(buf)
+
+	buf.reset()
+	if err := buf.encode(val); err != nil {
+		return nil, err
+	}
+	return buf.makeBytes(), nil
+}
+
+// Encode writes the RLP encoding of val to w.
+func Encode(w io.Writer, val interface{}) error {
+	buf := encbufPool.Get().(*encbuf)
+	defer encbufPool.Put(buf)
+
+	buf.reset()
+	if err := buf.encode(val); err != nil {
+		return err
+	}
+	return buf.toWriter(w)
+}
+
+var encbufPool = sync.Pool{
+	New: func() interface{} { return new(encbuf) },
+}
+
+// encbuf accumulates output data, delaying the computation of list headers
+// until the full content of the list is known. This is what allows
+// Encode/EncodeToBytes to avoid a separate size-measuring pass over val.
+type encbuf struct {
+	str    []byte      // string data, contains everything except list headers
+	lheads []*listhead // all list headers
+	lhsize int         // sum of sizes of all encoded list headers
+
+	sizebuf [9]byte // auxiliary buffer for uint and list header encoding
+}
+
+type listhead struct {
+	offset int // index of this header in str
+	size   int // total size of the list content, excluding this header
+}
+
+// encode implements the dispatch step shared by EncodeToBytes and Encode:
+// it resolves val's writer via the type cache and runs it against the buffer.
+func (w *encbuf) encode(val interface{}) error {
+	rval := reflect.ValueOf(val)
+	ti := cachedTypeInfo(rval.Type(), rlpstruct.Tags{})
+	if ti.writerErr != nil {
+		return ti.writerErr
+	}
+	return ti.writer(rval, w)
+}
+
+func (w *encbuf) reset() {
+	w.lhsize = 0
+	w.str = w.str[:0]
+	w.lheads = w.lheads[:0]
+}
+
+func (w *encbuf) size() int {
+	return len(w.str) + w.lhsize
+}
+
+// Write implements io.Writer so that a custom Encoder's EncodeRLP can write
+// directly into the buffer that the reflection-based writer is using.
+func (w *encbuf) Write(b []byte) (int, error) {
+	w.str = append(w.str, b...)
+	return len(b), nil
+}
+
+func (w *encbuf) writeBool(b bool) {
+	if b {
+		w.str = append(w.str, 0x01)
+	} else {
+		w.str = append(w.str, 0x80)
+	}
+}
+
+func (w *encbuf) writeUint64(i uint64) {
+	if i == 0 {
+		w.str = append(w.str, 0x80)
+	} else if i < 0x80 {
+		w.str = append(w.str, byte(i))
+	} else {
+		s := putint(w.sizebuf[1:], i)
+		w.sizebuf[0] = 0x80 + byte(s)
+		w.str = append(w.str, w.sizebuf[:s+1]...)
+	}
+}
+
+// writeBigInt writes i as an RLP string, using the minimal big-endian byte
+// representation. A nil pointer is treated as zero, matching writeUint64(0).
+func (w *encbuf) writeBigInt(i *big.Int) {
+	if i == nil {
+		w.str = append(w.str, 0x80)
+		return
+	}
+	if i.BitLen() <= 64 {
+		w.writeUint64(i.Uint64())
+		return
+	}
+	w.encodeStringHeader(len(i.Bytes()))
+	w.str = append(w.str, i.Bytes()...)
+}
+
+func (w *encbuf) writeBytes(b []byte) {
+	if len(b) == 1 && b[0] <= 0x7F {
+		// fits single byte, no string header
+		w.str = append(w.str, b[0])
+		return
+	}
+	w.encodeStringHeader(len(b))
+	w.str = append(w.str, b...)
+}
+
+func (w *encbuf) writeString(s string) {
+	w.writeBytes([]byte(s))
+}
+
+func (w *encbuf) encodeStringHeader(size int) {
+	if size < 56 {
+		w.str = append(w.str, 0x80+byte(size))
+	} else {
+		sizesize := putint(w.sizebuf[1:], uint64(size))
+		w.sizebuf[0] = 0xB7 + byte(sizesize)
+		w.str = append(w.str, w.sizebuf[:sizesize+1]...)
+	}
+}
+
+// list starts a list. The returned index must be passed to listEnd to
+// finish it.
+func (w *encbuf) list() int {
+	w.lheads = append(w.lheads, &listhead{offset: len(w.str), size: w.lhsize})
+	return len(w.lheads) - 1
+}
+
+func (w *encbuf) listEnd(index int) {
+	lh := w.lheads[index]
+	lh.size = w.size() - lh.offset - lh.size
+	if lh.size < 56 {
+		w.lhsize++
+	} else {
+		w.lhsize += 1 + intsize(uint64(lh.size))
+	}
+}
+
+func (head *listhead) encode(dst []byte) []byte {
+	return dst[:puthead(dst, 0xC0, 0xF7, uint64(head.size))]
+}
+
+// puthead writes a string/list header for the given size into buf, using
+// smalltag for sizes below 56 and largetag (plus the length-of-length) above
+// that, and returns the number of bytes written.
+func puthead(buf []byte, smalltag, largetag byte, size uint64) int {
+	if size < 56 {
+		buf[0] = smalltag + byte(size)
+		return 1
+	}
+	sizesize := putint(buf[1:], size)
+	buf[0] = largetag + byte(sizesize)
+	return sizesize + 1
+}
+
+// intsize returns the minimal number of big-endian bytes needed to hold val.
+func intsize(val uint64) int {
+	i := 1
+	for val >= 256 {
+		val >>= 8
+		i++
+	}
+	return i
+}
+
+// putint writes i to b as big-endian bytes, using the minimal length, and
+// returns that length. b must be at least 8 bytes long.
+func putint(b []byte, i uint64) int {
+	n := intsize(i)
+	for j := n - 1; j >= 0; j-- {
+		b[j] = byte(i)
+		i >>= 8
+	}
+	return n
+}
+
+func (w *encbuf) toWriter(out io.Writer) (err error) {
+	strpos := 0
+	for _, head := range w.lheads {
+		if head.offset-strpos > 0 {
+			n, err := out.Write(w.str[strpos:head.offset])
+			strpos += n
+			if err != nil {
+				return err
+			}
+		}
+		enc := head.encode(w.sizebuf[:])
+		if _, err = out.Write(enc); err != nil {
+			return err
+		}
+	}
+	if strpos < len(w.str) {
+		_, err = out.Write(w.str[strpos:])
+	}
+	return err
+}
+
+func (w *encbuf) makeBytes() []byte {
+	out := make([]byte, w.size())
+	w.copyTo(out)
+	return out
+}
+
+func (w *encbuf) copyTo(dst []byte) {
+	strpos, pos := 0, 0
+	for _, head := range w.lheads {
+		n := copy(dst[pos:], w.str[strpos:head.offset])
+		pos += n
+		strpos += n
+		enc := head.encode(dst[pos:])
+		pos += len(enc)
+	}
+	copy(dst[pos:], w.str[strpos:])
+}
+
+// makeWriter resolves the writer func for typ, honoring the rlp struct tags
+// that affect encoding (currently "optional", consulted by makeStructWriter,
+// and "nil"/"nilString"/"nilList", consulted by makePtrWriter).
+func makeWriter(typ reflect.Type, ts rlpstruct.Tags) (writer, error) {
+	kind := typ.Kind()
+	switch {
+	case typ == bigIntPtrType:
+		return writeBigIntPtr, nil
+	case kind == reflect.Ptr && typ.Implements(encoderInterface):
+		return writeEncoder, nil
+	case kind != reflect.Ptr && reflect.PtrTo(typ).Implements(encoderInterface):
+		return writeEncoderNoPtr, nil
+	case kind == reflect.Bool:
+		return writeBool, nil
+	case isUint(kind):
+		return writeUint, nil
+	case kind == reflect.String:
+		return writeString, nil
+	case kind == reflect.Slice || kind == reflect.Array:
+		return makeSliceWriter(typ)
+	case kind == reflect.Struct:
+		return makeStructWriter(typ)
+	case kind == reflect.Ptr:
+		return makePtrWriter(typ, ts)
+	default:
+		return nil, fmt.Errorf("rlp: type %v is not RLP-serializable", typ)
+	}
+}
+
+func writeBool(val reflect.Value, w *encbuf) error {
+	w.writeBool(val.Bool())
+	return nil
+}
+
+func writeUint(val reflect.Value, w *encbuf) error {
+	w.writeUint64(val.Uint())
+	return nil
+}
+
+func writeString(val reflect.Value, w *encbuf) error {
+	w.writeString(val.String())
+	return nil
+}
+
+func writeBigIntPtr(val reflect.Value, w *encbuf) error {
+	ptr := val.Interface().(*big.Int)
+	if ptr != nil && ptr.Sign() == -1 {
+		return errors.New("rlp: cannot encode negative big.Int")
+	}
+	w.writeBigInt(ptr)
+	return nil
+}
+
+func writeEncoder(val reflect.Value, w *encbuf) error {
+	return val.Interface().(Encoder).EncodeRLP(w)
+}
+
+// writeEncoderNoPtr handles non-pointer values whose pointer type implements
+// Encoder: it takes the address of val and calls EncodeRLP on that.
+func writeEncoderNoPtr(val reflect.Value, w *encbuf) error {
+	if !val.CanAddr() {
+		return fmt.Errorf("rlp: unadressable value of type %v, EncodeRLP is pointer method", val.Type())
+	}
+	return val.Addr().Interface().(Encoder).EncodeRLP(w)
+}
+
+// makeSliceWriter returns the writer for a slice or array type. Byte slices
+// and byte arrays are written as a single RLP string; everything else is
+// written as a list of the element writer's output.
+func makeSliceWriter(typ reflect.Type) (writer, error) {
+	etype := typ.Elem()
+	if etype.Kind() == reflect.Uint8 {
+		if typ.Kind() == reflect.Array {
+			return writeByteArray, nil
+		}
+		return writeByteSlice, nil
+	}
+	etypeinfo := theTC.infoWhileGenerating(etype, rlpstruct.Tags{})
+	if etypeinfo.writerErr != nil {
+		return nil, etypeinfo.writerErr
+	}
+	writer := func(val reflect.Value, w *encbuf) error {
+		lh := w.list()
+		for i := 0; i < val.Len(); i++ {
+			if err := etypeinfo.writer(val.Index(i), w); err != nil {
+				return err
+			}
+		}
+		w.listEnd(lh)
+		return nil
+	}
+	return writer, nil
+}
+
+func writeByteSlice(val reflect.Value, w *encbuf) error {
+	w.writeBytes(val.Bytes())
+	return nil
+}
+
+func writeByteArray(val reflect.Value, w *encbuf) error {
+	if !val.CanAddr() {
+		// Slicing an array requires it to be addressable.
+		cp := reflect.New(val.Type()).Elem()
+		cp.Set(val)
+		val = cp
+	}
+	size := val.Len()
+	w.writeBytes(val.Slice(0, size).Bytes())
+	return nil
+}
+
+// makePtrWriter returns the writer for a pointer type. A nil pointer is only
+// allowed when the field carries a "nil"/"nilString"/"nilList" tag; encoding
+// it then writes the empty value matching ts.NilKind (an empty RLP string or
+// an empty RLP list) instead of the elem's normal encoding.
+func makePtrWriter(typ reflect.Type, ts rlpstruct.Tags) (writer, error) {
+	etypeinfo := theTC.infoWhileGenerating(typ.Elem(), rlpstruct.Tags{})
+	if etypeinfo.writerErr != nil {
+		return nil, etypeinfo.writerErr
+	}
+	writer := func(val reflect.Value, w *encbuf) error {
+		if val.IsNil() {
+			if !ts.NilOK {
+				return fmt.Errorf("rlp: nil pointer of type %v without \"nil\" tag", typ)
+			}
+			if ts.NilKind == rlpstruct.NilKindString {
+				w.str = append(w.str, 0x80)
+			} else {
+				lh := w.list()
+				w.listEnd(lh)
+			}
+			return nil
+		}
+		return etypeinfo.writer(val.Elem(), w)
+	}
+	return writer, nil
+}
+
+// makeStructWriter returns the writer for a struct type. Fields tagged
+// "optional" are written up to the last one that is not at its zero value;
+// all earlier, non-optional fields are always written.
+func makeStructWriter(typ reflect.Type) (writer, error) {
+	fields, err := structFields(typ)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if f.info.writerErr != nil {
+			return nil, fmt.Errorf("rlp: field %q of %v: %v", typ.Field(f.index).Name, typ, f.info.writerErr)
+		}
+	}
+	writer := func(val reflect.Value, w *encbuf) error {
+		lh := w.list()
+		lastField := len(fields) - 1
+		for lastField >= 0 && fields[lastField].optional && val.Field(fields[lastField].index).IsZero() {
+			lastField--
+		}
+		for i := 0; i <= lastField; i++ {
+			f := fields[i]
+			if err := f.info.writer(val.Field(f.index), w); err != nil {
+				return err
+			}
+		}
+		w.listEnd(lh)
+		return nil
+	}
+	return writer, nil
+}
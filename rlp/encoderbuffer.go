@@ -0,0 +1,99 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rlp
+
+import (
+	"io"
+	"math/big"
+	"reflect"
+
+	"github.com/MatrixAINetwork/go-matrix/rlp/internal/rlpstruct"
+)
+
+// EncoderBuffer is a buffer for incremental encoding. It exposes the
+// primitives that rlpgen-generated EncodeRLP methods use to avoid the
+// reflection overhead of the default codec, while reusing the same
+// underlying encbuf that the reflect-based writer already relies on.
+type EncoderBuffer struct {
+	buf *encbuf
+	dst io.Writer
+}
+
+// NewEncoderBuffer creates an EncoderBuffer writing to dst.
+func NewEncoderBuffer(dst io.Writer) EncoderBuffer {
+	buf := encbufPool.Get().(*encbuf)
+	buf.reset()
+	return EncoderBuffer{buf: buf, dst: dst}
+}
+
+// Flush writes the accumulated data to the underlying writer and returns
+// the buffer to the pool.
+func (w EncoderBuffer) Flush() error {
+	err := w.buf.toWriter(w.dst)
+	encbufPool.Put(w.buf)
+	return err
+}
+
+// WriteUint64 writes an unsigned integer.
+func (w EncoderBuffer) WriteUint64(i uint64) {
+	w.buf.writeUint64(i)
+}
+
+// WriteBigInt writes a big.Int.
+func (w EncoderBuffer) WriteBigInt(i *big.Int) {
+	w.buf.writeBigInt(i)
+}
+
+// WriteBytes writes a byte slice as an RLP string.
+func (w EncoderBuffer) WriteBytes(b []byte) {
+	w.buf.writeBytes(b)
+}
+
+// WriteBool writes a bool.
+func (w EncoderBuffer) WriteBool(b bool) {
+	w.buf.writeBool(b)
+}
+
+// WriteString writes a Go string as an RLP string.
+func (w EncoderBuffer) WriteString(s string) {
+	w.buf.writeString(s)
+}
+
+// WriteAny writes val using the reflection-based encoder in this package.
+// rlpgen emits a call to this method for fields whose kind has no dedicated
+// EncoderBuffer primitive (structs, interfaces, and pointers other than
+// *big.Int), so generated EncodeRLP methods can write these fields too. val
+// is encoded without struct tags, as an untagged field would be.
+func (w EncoderBuffer) WriteAny(val interface{}) error {
+	rval := reflect.ValueOf(val)
+	ti := cachedTypeInfo(rval.Type(), rlpstruct.Tags{})
+	if ti.writerErr != nil {
+		return ti.writerErr
+	}
+	return ti.writer(rval, w.buf)
+}
+
+// List starts a list, returning the index of the list header that must
+// later be passed to ListEnd.
+func (w EncoderBuffer) List() int {
+	return w.buf.list()
+}
+
+// ListEnd finishes a list started with List.
+func (w EncoderBuffer) ListEnd(offset int) {
+	w.buf.listEnd(offset)
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// unencodableField has a kind (channel) that neither makeWriter nor
+// makeDecoder can handle, so genTypeInfo stashes an error in writerErr and
+// decoderErr instead of returning one.
+type unencodableField struct {
+	Ch chan int
+}
+
+// TestTypeInfoErrorIsCached checks that a type whose writer/decoder failed to
+// generate returns the same cached error on every subsequent call, rather
+// than re-running makeWriter/makeDecoder (or silently dropping the failed
+// entry so the next call tries again).
+func TestTypeInfoErrorIsCached(t *testing.T) {
+	typ := reflect.TypeOf(unencodableField{})
+
+	first := cachedTypeInfo(typ, tags{})
+	if first.writerErr == nil {
+		t.Fatal("writerErr = nil, want error for unencodable field")
+	}
+	if first.decoderErr == nil {
+		t.Fatal("decoderErr = nil, want error for unencodable field")
+	}
+
+	second := cachedTypeInfo(typ, tags{})
+	if second != first {
+		t.Fatal("cachedTypeInfo returned a different *typeinfo on the second call")
+	}
+	if second.writerErr != first.writerErr {
+		t.Errorf("writerErr changed between calls: %v != %v", second.writerErr, first.writerErr)
+	}
+
+	if _, err := EncodeToBytes(&unencodableField{}); err != first.writerErr {
+		t.Errorf("EncodeToBytes error = %v, want cached writerErr %v", err, first.writerErr)
+	}
+	if err := DecodeBytes([]byte{0xc0}, &unencodableField{}); err != first.decoderErr {
+		t.Errorf("DecodeBytes error = %v, want cached decoderErr %v", err, first.decoderErr)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package rlpstruct implements struct processing for RLP encoding/decoding.
+//
+// In particular, this package resolves the rlp struct tags and the list of
+// fields that should be encoded/decoded for a given struct type. Sharing
+// this logic between the reflect-based codec in package rlp and the rlpgen
+// code generator ensures both select the same fields of a Go struct, in the
+// same order, with the same tag validation.
+package rlpstruct
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Field represents a struct field.
+type Field struct {
+	Name     string
+	Index    int
+	Exported bool
+	Type     Type
+	Tag      string
+}
+
+// Type represents the attributes of a Go type.
+type Type struct {
+	Name      string
+	Kind      reflect.Kind
+	IsEncoder bool  // whether type implements rlp.Encoder
+	IsDecoder bool  // whether type implements rlp.Decoder
+	Elem      *Type // non-nil for Kind values of Ptr, Slice, Array
+
+	// PkgPath is the import path of the package the type is declared in.
+	// It is empty for unnamed and predeclared types.
+	PkgPath string
+}
+
+// DefaultNilValue determines whether a nil pointer of this type should be
+// encoded/decoded as an empty string or an empty list, used when a field
+// has the plain rlp:"nil" tag without an explicit nilString/nilList choice.
+// The elided value is chosen based on the pointed-to type's kind.
+func (t Type) DefaultNilValue() NilKind {
+	elem := t
+	if t.Kind == reflect.Ptr && t.Elem != nil {
+		elem = *t.Elem
+	}
+	if elem.IsEncoder {
+		return NilKindString
+	}
+	k := elem.Kind
+	if isUint(k) || k == reflect.String || k == reflect.Bool || isByteArray(elem) {
+		return NilKindString
+	}
+	return NilKindList
+}
+
+// NilKind is the RLP value encoded in place of a nil pointer.
+type NilKind uint8
+
+const (
+	NilKindString NilKind = 0x80
+	NilKindList   NilKind = 0xC0
+)
+
+// Tags represents struct tags.
+type Tags struct {
+	// rlp:"nil" controls whether empty input results in a nil pointer.
+	// NilKind is the kind of empty value allowed for the field.
+	NilOK   bool
+	NilKind NilKind
+	// rlp:"optional" means the field is allowed to be missing in the input
+	// list. If this is set, all subsequent fields must also be optional,
+	// except for a trailing "tail" field.
+	Optional bool
+	// rlp:"tail" marks the field that swallows additional list elements.
+	// It can only be set for the last field, which must be of slice type.
+	// ProcessFields validates its placement, but the struct codecs in
+	// package rlp and in rlpgen do not apply it: a tail field is encoded
+	// and decoded like any other slice field, as one item of the list.
+	Tail bool
+	// rlp:"-" ignores fields.
+	Ignored bool
+}
+
+// TagError is raised for invalid struct tags.
+type TagError struct {
+	StructType string
+
+	Field string
+	Tag   string
+	Err   string
+}
+
+func (e *TagError) Error() string {
+	field := "field " + e.Field
+	if e.StructType != "" {
+		field = e.StructType + "." + e.Field
+	}
+	return fmt.Sprintf("rlp: invalid struct tag %q for %s (%s)", e.Tag, field, e.Err)
+}
+
+// ProcessFields filters the given set of struct fields, returning only those
+// fields that should be encoded/decoded, along with their parsed rlp tags.
+func ProcessFields(allFields []Field) ([]Field, []Tags, error) {
+	lastPublic := lastPublicField(allFields)
+
+	// Gather the exported, non-ignored fields and their parsed tags.
+	var fields []Field
+	var tags []Tags
+	for _, field := range allFields {
+		if !field.Exported {
+			continue
+		}
+		ts, err := parseTag(field, lastPublic)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ts.Ignored {
+			continue
+		}
+		fields = append(fields, field)
+		tags = append(tags, ts)
+	}
+
+	// Verify optional field consistency. Once a field is tagged optional,
+	// every subsequent field (other than a trailing "tail" field) must also
+	// be optional.
+	var anyOptional bool
+	var firstOptionalName string
+	for i, ts := range tags {
+		if ts.Optional {
+			if !anyOptional {
+				anyOptional = true
+				firstOptionalName = fields[i].Name
+			}
+			continue
+		}
+		if ts.Tail {
+			continue
+		}
+		if anyOptional {
+			return nil, nil, &TagError{
+				Field: fields[i].Name,
+				Err:   fmt.Sprintf("must be optional because preceding field %q is optional", firstOptionalName),
+			}
+		}
+	}
+	return fields, tags, nil
+}
+
+func parseTag(field Field, lastPublic int) (Tags, error) {
+	name := field.Name
+	tag := reflect.StructTag(field.Tag)
+	var ts Tags
+	for _, t := range strings.Split(tag.Get("rlp"), ",") {
+		switch t = strings.TrimSpace(t); t {
+		case "":
+		case "-":
+			ts.Ignored = true
+		case "nil":
+			ts.NilOK = true
+			ts.NilKind = field.Type.DefaultNilValue()
+		case "nilString":
+			ts.NilOK = true
+			ts.NilKind = NilKindString
+		case "nilList":
+			ts.NilOK = true
+			ts.NilKind = NilKindList
+		case "optional":
+			ts.Optional = true
+			if ts.Tail {
+				return ts, &TagError{Field: name, Tag: "optional", Err: `also has "tail"`}
+			}
+		case "tail":
+			ts.Tail = true
+			if field.Index != lastPublic {
+				return ts, &TagError{Field: name, Tag: "tail", Err: "must be on last field"}
+			}
+			if ts.Optional {
+				return ts, &TagError{Field: name, Tag: "tail", Err: `also has "optional"`}
+			}
+			if field.Type.Kind != reflect.Slice {
+				return ts, &TagError{Field: name, Tag: "tail", Err: "field type is not slice"}
+			}
+		default:
+			return ts, &TagError{Field: name, Tag: t, Err: "unknown tag"}
+		}
+	}
+	return ts, nil
+}
+
+// lastPublicField returns the index of the last exported field, ignoring
+// any unexported fields that might follow it.
+func lastPublicField(fields []Field) int {
+	last := 0
+	for _, f := range fields {
+		if f.Exported {
+			last = f.Index
+		}
+	}
+	return last
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isByteArray(t Type) bool {
+	return (t.Kind == reflect.Slice || t.Kind == reflect.Array) && t.Elem != nil && t.Elem.Kind == reflect.Uint8
+}
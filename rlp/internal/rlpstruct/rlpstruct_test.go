@@ -0,0 +1,196 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rlpstruct
+
+import (
+	"reflect"
+	"testing"
+)
+
+func uintField(name string, index int) Field {
+	return Field{Name: name, Index: index, Exported: true, Type: Type{Kind: reflect.Uint64}}
+}
+
+func TestProcessFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    []Field
+		wantNames []string
+		wantTags  []Tags
+		wantErr   string
+	}{
+		{
+			name: "plain fields are kept in order",
+			fields: []Field{
+				uintField("A", 0),
+				uintField("B", 1),
+			},
+			wantNames: []string{"A", "B"},
+			wantTags:  []Tags{{}, {}},
+		},
+		{
+			name: "unexported fields are dropped",
+			fields: []Field{
+				uintField("A", 0),
+				{Name: "b", Index: 1, Exported: false, Type: Type{Kind: reflect.Uint64}},
+				uintField("C", 2),
+			},
+			wantNames: []string{"A", "C"},
+			wantTags:  []Tags{{}, {}},
+		},
+		{
+			name: "rlp:\"-\" fields are dropped",
+			fields: []Field{
+				uintField("A", 0),
+				{Name: "B", Index: 1, Exported: true, Tag: `rlp:"-"`, Type: Type{Kind: reflect.Uint64}},
+				uintField("C", 2),
+			},
+			wantNames: []string{"A", "C"},
+			wantTags:  []Tags{{}, {}},
+		},
+		{
+			name: "trailing optional fields are allowed",
+			fields: []Field{
+				uintField("A", 0),
+				{Name: "B", Index: 1, Exported: true, Tag: `rlp:"optional"`, Type: Type{Kind: reflect.Uint64}},
+				{Name: "C", Index: 2, Exported: true, Tag: `rlp:"optional"`, Type: Type{Kind: reflect.Uint64}},
+			},
+			wantNames: []string{"A", "B", "C"},
+			wantTags:  []Tags{{}, {Optional: true}, {Optional: true}},
+		},
+		{
+			name: "non-optional field after an optional one is rejected",
+			fields: []Field{
+				uintField("A", 0),
+				{Name: "B", Index: 1, Exported: true, Tag: `rlp:"optional"`, Type: Type{Kind: reflect.Uint64}},
+				uintField("C", 2),
+			},
+			wantErr: `rlp: invalid struct tag "" for field C (must be optional because preceding field "B" is optional)`,
+		},
+		{
+			name: "optional combined with tail is rejected",
+			fields: []Field{
+				{Name: "A", Index: 0, Exported: true, Tag: `rlp:"optional,tail"`, Type: Type{Kind: reflect.Slice, Elem: &Type{Kind: reflect.Uint64}}},
+			},
+			wantErr: `rlp: invalid struct tag "tail" for field A (also has "optional")`,
+		},
+		{
+			name: "tail combined with optional is rejected",
+			fields: []Field{
+				{Name: "A", Index: 0, Exported: true, Tag: `rlp:"tail,optional"`, Type: Type{Kind: reflect.Slice, Elem: &Type{Kind: reflect.Uint64}}},
+			},
+			wantErr: `rlp: invalid struct tag "optional" for field A (also has "tail")`,
+		},
+		{
+			name: "tail must be on the last field",
+			fields: []Field{
+				{Name: "A", Index: 0, Exported: true, Tag: `rlp:"tail"`, Type: Type{Kind: reflect.Slice, Elem: &Type{Kind: reflect.Uint64}}},
+				uintField("B", 1),
+			},
+			wantErr: `rlp: invalid struct tag "tail" for field A (must be on last field)`,
+		},
+		{
+			name: "tail field must be a slice",
+			fields: []Field{
+				{Name: "A", Index: 0, Exported: true, Tag: `rlp:"tail"`, Type: Type{Kind: reflect.Uint64}},
+			},
+			wantErr: `rlp: invalid struct tag "tail" for field A (field type is not slice)`,
+		},
+		{
+			name: "unknown tag is rejected",
+			fields: []Field{
+				{Name: "A", Index: 0, Exported: true, Tag: `rlp:"bogus"`, Type: Type{Kind: reflect.Uint64}},
+			},
+			wantErr: `rlp: invalid struct tag "bogus" for field A (unknown tag)`,
+		},
+		{
+			name: "nilString sets NilKindString regardless of field type",
+			fields: []Field{
+				{Name: "A", Index: 0, Exported: true, Tag: `rlp:"nilString"`, Type: Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Struct}}},
+			},
+			wantNames: []string{"A"},
+			wantTags:  []Tags{{NilOK: true, NilKind: NilKindString}},
+		},
+		{
+			name: "nilList sets NilKindList regardless of field type",
+			fields: []Field{
+				{Name: "A", Index: 0, Exported: true, Tag: `rlp:"nilList"`, Type: Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Uint64}}},
+			},
+			wantNames: []string{"A"},
+			wantTags:  []Tags{{NilOK: true, NilKind: NilKindList}},
+		},
+		{
+			name: "plain nil uses the pointed-to type's default nil kind",
+			fields: []Field{
+				{Name: "A", Index: 0, Exported: true, Tag: `rlp:"nil"`, Type: Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Uint64}}},
+				{Name: "B", Index: 1, Exported: true, Tag: `rlp:"nil"`, Type: Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Struct}}},
+			},
+			wantNames: []string{"A", "B"},
+			wantTags:  []Tags{{NilOK: true, NilKind: NilKindString}, {NilOK: true, NilKind: NilKindList}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fields, tags, err := ProcessFields(test.fields)
+			if test.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got nil", test.wantErr)
+				}
+				if err.Error() != test.wantErr {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", err.Error(), test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var gotNames []string
+			for _, f := range fields {
+				gotNames = append(gotNames, f.Name)
+			}
+			if !reflect.DeepEqual(gotNames, test.wantNames) {
+				t.Errorf("wrong field names: got %v, want %v", gotNames, test.wantNames)
+			}
+			if !reflect.DeepEqual(tags, test.wantTags) {
+				t.Errorf("wrong tags: got %+v, want %+v", tags, test.wantTags)
+			}
+		})
+	}
+}
+
+func TestTypeDefaultNilValue(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  Type
+		want NilKind
+	}{
+		{"pointer to uint", Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Uint64}}, NilKindString},
+		{"pointer to bool", Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Bool}}, NilKindString},
+		{"pointer to string", Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.String}}, NilKindString},
+		{"pointer to byte array", Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Array, Elem: &Type{Kind: reflect.Uint8}}}, NilKindString},
+		{"pointer to struct", Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Struct}}, NilKindList},
+		{"pointer to non-byte slice", Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Slice, Elem: &Type{Kind: reflect.Uint64}}}, NilKindList},
+		{"pointer to rlp.Encoder implementation", Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Struct, IsEncoder: true}}, NilKindString},
+	}
+	for _, test := range tests {
+		if got := test.typ.DefaultNilValue(); got != test.want {
+			t.Errorf("%s: DefaultNilValue() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rlp
+
+import "testing"
+
+type nilStringField struct {
+	A *uint64 `rlp:"nilString"`
+}
+
+type nilListField struct {
+	A *uint64 `rlp:"nilList"`
+}
+
+func TestNilKindRoundTrip(t *testing.T) {
+	enc, err := EncodeToBytes(&nilStringField{A: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got nilStringField
+	if err := DecodeBytes(enc, &got); err != nil {
+		t.Fatalf("DecodeBytes: %v", err)
+	}
+	if got.A != nil {
+		t.Errorf("A = %v, want nil", got.A)
+	}
+
+	v := uint64(9)
+	enc, err = EncodeToBytes(&nilStringField{A: &v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nilStringField{}
+	if err := DecodeBytes(enc, &got); err != nil {
+		t.Fatalf("DecodeBytes: %v", err)
+	}
+	if got.A == nil || *got.A != v {
+		t.Errorf("A = %v, want %d", got.A, v)
+	}
+}
+
+func TestNilListRoundTrip(t *testing.T) {
+	enc, err := EncodeToBytes(&nilListField{A: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got nilListField
+	if err := DecodeBytes(enc, &got); err != nil {
+		t.Fatalf("DecodeBytes: %v", err)
+	}
+	if got.A != nil {
+		t.Errorf("A = %v, want nil", got.A)
+	}
+}
+
+// TestNilKindRejectsWrongEmptyKind verifies that decoding an empty value of
+// the wrong kind (an empty list where the field expects an empty string, or
+// vice versa) is rejected instead of silently accepted as nil.
+func TestNilKindRejectsWrongEmptyKind(t *testing.T) {
+	enc, err := EncodeToBytes(&nilListField{A: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wrong nilStringField
+	if err := DecodeBytes(enc, &wrong); err == nil {
+		t.Fatal("DecodeBytes succeeded decoding an empty list into a nilString field, want error")
+	}
+
+	enc, err = EncodeToBytes(&nilStringField{A: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wrong2 nilListField
+	if err := DecodeBytes(enc, &wrong2); err == nil {
+		t.Fatal("DecodeBytes succeeded decoding an empty string into a nilList field, want error")
+	}
+}
+
+func TestMissingNonNilPointerIsError(t *testing.T) {
+	type required struct {
+		A *uint64
+	}
+	enc, err := EncodeToBytes(&struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got required
+	if err := DecodeBytes(enc, &got); err == nil {
+		t.Fatal("expected error decoding into struct with missing non-nilable pointer field")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rlp
+
+import "testing"
+
+type optionalFields struct {
+	A uint64
+	B uint64 `rlp:"optional"`
+	C uint64 `rlp:"optional"`
+}
+
+func TestOptionalFieldsRoundTrip(t *testing.T) {
+	tests := []optionalFields{
+		{A: 1},
+		{A: 1, B: 2},
+		{A: 1, C: 3},
+		{A: 1, B: 2, C: 3},
+	}
+	for _, want := range tests {
+		enc, err := EncodeToBytes(&want)
+		if err != nil {
+			t.Fatalf("EncodeToBytes(%+v): %v", want, err)
+		}
+		var got optionalFields
+		if err := DecodeBytes(enc, &got); err != nil {
+			t.Fatalf("DecodeBytes(%+v): %v", want, err)
+		}
+		if got != want {
+			t.Errorf("roundtrip of %+v = %+v", want, got)
+		}
+	}
+}
+
+// TestOptionalFieldsEncodingDropsTrailingZeros verifies that trailing
+// optional fields at their zero value are actually elided from the wire
+// encoding, not just accepted by the tag parser.
+func TestOptionalFieldsEncodingDropsTrailingZeros(t *testing.T) {
+	trimmed, err := EncodeToBytes(&optionalFields{A: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := EncodeToBytes(&optionalFields{A: 1, B: 2, C: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trimmed) >= len(full) {
+		t.Fatalf("encoding with zero-valued optional fields (%d bytes) is not shorter than full encoding (%d bytes)", len(trimmed), len(full))
+	}
+}
+
+// TestOptionalFieldsDecodeMissingLeavesZero verifies that decoding a list
+// shorter than the struct (as produced by dropping trailing optional fields)
+// leaves the missing fields at their zero value instead of erroring.
+func TestOptionalFieldsDecodeMissingLeavesZero(t *testing.T) {
+	type partial struct {
+		A uint64
+	}
+	enc, err := EncodeToBytes(&partial{A: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got optionalFields
+	if err := DecodeBytes(enc, &got); err != nil {
+		t.Fatalf("DecodeBytes: %v", err)
+	}
+	if want := (optionalFields{A: 7}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestNonOptionalFieldMissingIsError verifies that a missing non-optional
+// field is still rejected, even though optional fields may be missing.
+func TestNonOptionalFieldMissingIsError(t *testing.T) {
+	enc, err := EncodeToBytes(&struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got optionalFields
+	if err := DecodeBytes(enc, &got); err == nil {
+		t.Fatal("expected error decoding into struct with missing non-optional field")
+	}
+}
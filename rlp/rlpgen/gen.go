@@ -0,0 +1,406 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/MatrixAINetwork/go-matrix/rlp/internal/rlpstruct"
+)
+
+// buildContext carries the information needed to generate EncodeRLP/DecodeRLP
+// source for a single named struct type. It walks fields using the same
+// rlpstruct rules applied by the reflection-based codec in package rlp, so
+// both select the same fields in the same order. The "nil", "nilString" and
+// "nilList" tags are not applied by the generated code: pointer fields other
+// than *big.Int are written with WriteAny and read with Stream.Decode without
+// their tags, so a nil pointer is not encoded as an empty value and an empty
+// value is not decoded as nil.
+type buildContext struct {
+	pkg *packages.Package
+
+	encoderIface *types.Interface
+	decoderIface *types.Interface
+}
+
+func newBuildContext(pkg *packages.Package) *buildContext {
+	enc, dec := rlpPackageInterfaces(pkg)
+	return &buildContext{pkg: pkg, encoderIface: enc, decoderIface: dec}
+}
+
+// rlpPackageInterfaces locates rlp.Encoder/rlp.Decoder in the type-checked
+// rlp package so generated code can tell which fields already implement
+// their own (de)serialization.
+func rlpPackageInterfaces(pkg *packages.Package) (enc, dec *types.Interface) {
+	for path, imp := range pkg.Imports {
+		if path != "github.com/MatrixAINetwork/go-matrix/rlp" {
+			continue
+		}
+		if o := imp.Types.Scope().Lookup("Encoder"); o != nil {
+			enc, _ = o.Type().Underlying().(*types.Interface)
+		}
+		if o := imp.Types.Scope().Lookup("Decoder"); o != nil {
+			dec, _ = o.Type().Underlying().(*types.Interface)
+		}
+	}
+	return enc, dec
+}
+
+// typeToStructType converts a go/types.Type into the codec-neutral
+// rlpstruct.Type so field processing rules (struct tags, nil-kind defaults)
+// can be shared between this generator and the reflect-based fallback in
+// package rlp. Elem is filled in recursively for Ptr/Slice/Array types so
+// later passes (e.g. picking the *big.Int fast path, or checking for a
+// byte slice) can inspect the pointed-to/element type.
+func (bctx *buildContext) typeToStructType(typ types.Type) rlpstruct.Type {
+	var name, pkgPath string
+	if named, ok := typ.(*types.Named); ok {
+		name = named.Obj().Name()
+		if named.Obj().Pkg() != nil {
+			pkgPath = named.Obj().Pkg().Path()
+		}
+	}
+	rt := rlpstruct.Type{
+		Name:    name,
+		Kind:    typeReflectKind(typ),
+		PkgPath: pkgPath,
+	}
+	if bctx.encoderIface != nil {
+		rt.IsEncoder = types.Implements(typ, bctx.encoderIface) || types.Implements(types.NewPointer(typ), bctx.encoderIface)
+	}
+	if bctx.decoderIface != nil {
+		rt.IsDecoder = types.Implements(types.NewPointer(typ), bctx.decoderIface)
+	}
+	switch u := typ.Underlying().(type) {
+	case *types.Pointer:
+		elem := bctx.typeToStructType(u.Elem())
+		rt.Elem = &elem
+	case *types.Slice:
+		elem := bctx.typeToStructType(u.Elem())
+		rt.Elem = &elem
+	case *types.Array:
+		elem := bctx.typeToStructType(u.Elem())
+		rt.Elem = &elem
+	}
+	return rt
+}
+
+// typeReflectKind maps a go/types.Type to the reflect.Kind rlpstruct works
+// with, so the generator's view of a type's shape matches what reflection
+// would report for the same Go declaration. Integer widths are preserved
+// (rather than collapsed to reflect.Uint/reflect.Int) because later passes
+// need to tell a byte slice's element kind (reflect.Uint8) apart from, say,
+// a uint32 slice's.
+func typeReflectKind(typ types.Type) reflect.Kind {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch t.Kind() {
+		case types.Bool:
+			return reflect.Bool
+		case types.String:
+			return reflect.String
+		case types.Uint:
+			return reflect.Uint
+		case types.Uint8:
+			return reflect.Uint8
+		case types.Uint16:
+			return reflect.Uint16
+		case types.Uint32:
+			return reflect.Uint32
+		case types.Uint64:
+			return reflect.Uint64
+		case types.Uintptr:
+			return reflect.Uintptr
+		case types.Int:
+			return reflect.Int
+		case types.Int8:
+			return reflect.Int8
+		case types.Int16:
+			return reflect.Int16
+		case types.Int32:
+			return reflect.Int32
+		case types.Int64:
+			return reflect.Int64
+		}
+	case *types.Struct:
+		return reflect.Struct
+	case *types.Pointer:
+		return reflect.Ptr
+	case *types.Slice:
+		return reflect.Slice
+	case *types.Array:
+		return reflect.Array
+	case *types.Interface:
+		return reflect.Interface
+	}
+	return reflect.Invalid
+}
+
+// structFields resolves the encodable fields of a struct type, delegating
+// tag parsing and ordering rules (including "optional") to
+// rlpstruct.ProcessFields so the generator stays in lockstep with the
+// reflect-based encoder/decoder.
+func (bctx *buildContext) structFields(typ *types.Struct) ([]rlpstruct.Field, []rlpstruct.Tags, error) {
+	var allFields []rlpstruct.Field
+	for i := 0; i < typ.NumFields(); i++ {
+		f := typ.Field(i)
+		allFields = append(allFields, rlpstruct.Field{
+			Name:     f.Name(),
+			Index:    i,
+			Exported: f.Exported(),
+			Tag:      typ.Tag(i),
+			Type:     bctx.typeToStructType(f.Type()),
+		})
+	}
+	return rlpstruct.ProcessFields(allFields)
+}
+
+// generateEncoder emits the body of an EncodeRLP method for typeName, using
+// an rlp.EncoderBuffer to write the list header and each field in turn.
+// Trailing fields tagged "optional" are only written up to the last
+// non-zero one, matching the reflect-based writer's behavior.
+func (bctx *buildContext) generateEncoder(typeName string, st *types.Struct) ([]byte, error) {
+	fields, tags, err := bctx.structFields(st)
+	if err != nil {
+		return nil, fmt.Errorf("rlpgen: %v", err)
+	}
+
+	// Optional fields are always the trailing run of fields (ProcessFields
+	// rejects any other arrangement), so firstOptional marks where that run
+	// starts.
+	firstOptional := len(fields)
+	for i, t := range tags {
+		if t.Optional {
+			firstOptional = i
+			break
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "func (obj *%s) EncodeRLP(_w io.Writer) error {\n", typeName)
+	out.WriteString("\tw := rlp.NewEncoderBuffer(_w)\n")
+
+	// For each optional field, _haveN reports whether that field or any
+	// optional field after it is non-zero. A field is written whenever
+	// _haveN is true, so a zero-valued optional field is only dropped when
+	// every optional field following it is also zero.
+	for i := len(fields) - 1; i >= firstOptional; i-- {
+		check := zeroValueCheck(fields[i])
+		if i == len(fields)-1 {
+			fmt.Fprintf(&out, "\t_have%d := %s\n", i, check)
+		} else {
+			fmt.Fprintf(&out, "\t_have%d := %s || _have%d\n", i, check, i+1)
+		}
+	}
+
+	out.WriteString("\t_tmp0 := w.List()\n")
+	for i, f := range fields {
+		if tags[i].Optional {
+			fmt.Fprintf(&out, "\tif _have%d {\n", i)
+		}
+		if err := writeEncoderBufferCall(&out, f); err != nil {
+			return nil, fmt.Errorf("rlpgen: %v", err)
+		}
+		if tags[i].Optional {
+			out.WriteString("\t}\n")
+		}
+	}
+	out.WriteString("\tw.ListEnd(_tmp0)\n")
+	out.WriteString("\treturn w.Flush()\n")
+	out.WriteString("}\n")
+	return out.Bytes(), nil
+}
+
+// zeroValueCheck returns a Go boolean expression that is true when field f
+// of obj is not at its zero value.
+func zeroValueCheck(f rlpstruct.Field) string {
+	expr := "obj." + f.Name
+	switch f.Type.Kind {
+	case reflect.Bool:
+		return expr
+	case reflect.String:
+		return expr + ` != ""`
+	case reflect.Slice:
+		return expr + " != nil"
+	case reflect.Array:
+		return expr + " != [len(" + expr + ")]byte{}"
+	case reflect.Ptr, reflect.Interface:
+		return expr + " != nil"
+	default:
+		return expr + " != 0"
+	}
+}
+
+// writeEncoderBufferCall emits the statement that writes field f's value
+// into w. Kinds with a dedicated EncoderBuffer primitive are written
+// directly; everything else (structs, interfaces, and pointers other than
+// *big.Int) falls back to the reflection-based encoder via w.WriteAny, whose
+// error is propagated.
+func writeEncoderBufferCall(out *bytes.Buffer, f rlpstruct.Field) error {
+	expr := "obj." + f.Name
+	switch f.Type.Kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprintf(out, "\tw.WriteUint64(uint64(%s))\n", expr)
+	case reflect.Bool:
+		fmt.Fprintf(out, "\tw.WriteBool(%s)\n", expr)
+	case reflect.String:
+		fmt.Fprintf(out, "\tw.WriteString(%s)\n", expr)
+	case reflect.Slice, reflect.Array:
+		if f.Type.Elem == nil || f.Type.Elem.Kind != reflect.Uint8 {
+			return fmt.Errorf("field %s has unsupported element type for generated encoding", f.Name)
+		}
+		if f.Type.Kind == reflect.Array {
+			expr += "[:]"
+		}
+		fmt.Fprintf(out, "\tw.WriteBytes(%s)\n", expr)
+	case reflect.Ptr:
+		if f.Type.Elem != nil && f.Type.Elem.PkgPath == "math/big" && f.Type.Elem.Name == "Int" {
+			fmt.Fprintf(out, "\tw.WriteBigInt(%s)\n", expr)
+			return nil
+		}
+		fmt.Fprintf(out, "\tif err := w.WriteAny(%s); err != nil {\n\t\treturn err\n\t}\n", expr)
+	case reflect.Struct, reflect.Interface:
+		fmt.Fprintf(out, "\tif err := w.WriteAny(%s); err != nil {\n\t\treturn err\n\t}\n", expr)
+	default:
+		return fmt.Errorf("field %s has unsupported type for generated encoding", f.Name)
+	}
+	return nil
+}
+
+// generateDecoder emits the body of a DecodeRLP method for typeName, using
+// an rlp.Stream to read the list header and each field in turn. Trailing
+// fields tagged "optional" are only read while the list still has data,
+// matching the reflect-based decoder's behavior of leaving missing trailing
+// optional fields at their zero value.
+func (bctx *buildContext) generateDecoder(typeName string, st *types.Struct) ([]byte, error) {
+	fields, tags, err := bctx.structFields(st)
+	if err != nil {
+		return nil, fmt.Errorf("rlpgen: %v", err)
+	}
+
+	firstOptional := len(fields)
+	for i, t := range tags {
+		if t.Optional {
+			firstOptional = i
+			break
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "func (obj *%s) DecodeRLP(_s *rlp.Stream) error {\n", typeName)
+	out.WriteString("\tif _, err := _s.List(); err != nil {\n\t\treturn err\n\t}\n")
+	for i, f := range fields {
+		if i >= firstOptional {
+			out.WriteString("\tif !_s.MoreDataInList() {\n\t\treturn _s.ListEnd()\n\t}\n")
+		}
+		if err := readStreamCall(&out, f); err != nil {
+			return nil, fmt.Errorf("rlpgen: %v", err)
+		}
+	}
+	out.WriteString("\treturn _s.ListEnd()\n")
+	out.WriteString("}\n")
+	return out.Bytes(), nil
+}
+
+// streamAssignFmt is shared by every integer width: it reads the value with
+// the named rlp.Stream method and assigns it into the field, explicitly
+// converted to the field's own type (which may be a named integer type).
+const streamAssignFmt = "\tif v, err := _s.%s(); err != nil {\n\t\treturn err\n\t} else {\n\t\t%s = %s(v)\n\t}\n"
+
+// namedTypeString returns the Go type name to use when converting a decoded
+// primitive value into field f. Named types are generated into the same
+// package as the struct they belong to, so the bare name is sufficient;
+// unnamed fields fall back to their kind's predeclared type name.
+func namedTypeString(f rlpstruct.Field) string {
+	if f.Type.Name != "" {
+		return f.Type.Name
+	}
+	return f.Type.Kind.String()
+}
+
+// readStreamCall emits the statement that reads field f's value from _s into
+// obj. This mirrors writeEncoderBufferCall's kind dispatch; fields without a
+// dedicated Stream primitive (structs, interfaces, and pointers other than
+// *big.Int) fall back to the reflection-based decoder via _s.Decode.
+func readStreamCall(out *bytes.Buffer, f rlpstruct.Field) error {
+	expr := "obj." + f.Name
+	switch f.Type.Kind {
+	case reflect.Uint8:
+		fmt.Fprintf(out, streamAssignFmt, "Uint8", expr, namedTypeString(f))
+	case reflect.Uint16:
+		fmt.Fprintf(out, streamAssignFmt, "Uint16", expr, namedTypeString(f))
+	case reflect.Uint32:
+		fmt.Fprintf(out, streamAssignFmt, "Uint32", expr, namedTypeString(f))
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprintf(out, streamAssignFmt, "Uint64", expr, namedTypeString(f))
+	case reflect.Bool:
+		fmt.Fprintf(out, "\tif v, err := _s.Bool(); err != nil {\n\t\treturn err\n\t} else {\n\t\t%s = v\n\t}\n", expr)
+	case reflect.String:
+		fmt.Fprintf(out, "\tif v, err := _s.Bytes(); err != nil {\n\t\treturn err\n\t} else {\n\t\t%s = string(v)\n\t}\n", expr)
+	case reflect.Slice, reflect.Array:
+		if f.Type.Elem == nil || f.Type.Elem.Kind != reflect.Uint8 {
+			return fmt.Errorf("field %s has unsupported element type for generated decoding", f.Name)
+		}
+		if f.Type.Kind == reflect.Array {
+			fmt.Fprintf(out, "\tif err := _s.ReadBytes(%s[:]); err != nil {\n\t\treturn err\n\t}\n", expr)
+		} else {
+			fmt.Fprintf(out, "\tif v, err := _s.Bytes(); err != nil {\n\t\treturn err\n\t} else {\n\t\t%s = v\n\t}\n", expr)
+		}
+	case reflect.Ptr:
+		if f.Type.Elem != nil && f.Type.Elem.PkgPath == "math/big" && f.Type.Elem.Name == "Int" {
+			fmt.Fprintf(out, "\tif v, err := _s.BigInt(); err != nil {\n\t\treturn err\n\t} else {\n\t\t%s = v\n\t}\n", expr)
+			return nil
+		}
+		fmt.Fprintf(out, "\tif err := _s.Decode(&%s); err != nil {\n\t\treturn err\n\t}\n", expr)
+	case reflect.Struct, reflect.Interface:
+		fmt.Fprintf(out, "\tif err := _s.Decode(&%s); err != nil {\n\t\treturn err\n\t}\n", expr)
+	default:
+		return fmt.Errorf("field %s has unsupported type for generated decoding", f.Name)
+	}
+	return nil
+}
+
+func generate(pkg *packages.Package, obj types.Object, genDecoder bool) ([]byte, error) {
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a named type", obj.Name())
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", obj.Name())
+	}
+	bctx := newBuildContext(pkg)
+	body, err := bctx.generateEncoder(obj.Name(), st)
+	if err != nil {
+		return nil, err
+	}
+	if genDecoder {
+		decBody, err := bctx.generateDecoder(obj.Name(), st)
+		if err != nil {
+			return nil, err
+		}
+		body = append(append(body, '\n'), decBody...)
+	}
+	src := []byte(fmt.Sprintf("// Code generated by rlpgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n\t\"io\"\n\n\t\"github.com/MatrixAINetwork/go-matrix/rlp\"\n)\n\n%s", pkg.Name, body))
+	return format.Source(src)
+}
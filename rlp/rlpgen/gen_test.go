@@ -0,0 +1,159 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"go/token"
+	"go/types"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// These are golden-output tests for generateEncoder/generateDecoder: they
+// build a types.Struct by hand (no package loading required) and check the
+// generated source against the exact expected output, so a change to the
+// codegen templates that breaks the wire format is caught here rather than
+// only by running rlpgen on a real package.
+
+func newField(name string, typ types.Type) *types.Var {
+	return types.NewField(token.NoPos, nil, name, typ, false)
+}
+
+func TestGenerateEncoderGolden(t *testing.T) {
+	st := types.NewStruct(
+		[]*types.Var{
+			newField("A", types.Typ[types.Uint64]),
+			newField("B", types.Typ[types.Uint64]),
+		},
+		[]string{``, `rlp:"optional"`},
+	)
+	bctx := newBuildContext(&packages.Package{})
+	code, err := bctx.generateEncoder("Test", st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `func (obj *Test) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_have1 := obj.B != 0
+	_tmp0 := w.List()
+	w.WriteUint64(uint64(obj.A))
+	if _have1 {
+	w.WriteUint64(uint64(obj.B))
+	}
+	w.ListEnd(_tmp0)
+	return w.Flush()
+}
+`
+	if string(code) != want {
+		t.Errorf("generateEncoder output mismatch\n--- got ---\n%s\n--- want ---\n%s", code, want)
+	}
+}
+
+func TestGenerateDecoderGolden(t *testing.T) {
+	st := types.NewStruct(
+		[]*types.Var{
+			newField("A", types.Typ[types.Uint64]),
+			newField("Buf", types.NewArray(types.Typ[types.Uint8], 4)),
+			newField("Opt", types.Typ[types.Uint64]),
+		},
+		[]string{``, ``, `rlp:"optional"`},
+	)
+	bctx := newBuildContext(&packages.Package{})
+	code, err := bctx.generateDecoder("Test", st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `func (obj *Test) DecodeRLP(_s *rlp.Stream) error {
+	if _, err := _s.List(); err != nil {
+		return err
+	}
+	if v, err := _s.Uint64(); err != nil {
+		return err
+	} else {
+		obj.A = uint64(v)
+	}
+	if err := _s.ReadBytes(obj.Buf[:]); err != nil {
+		return err
+	}
+	if !_s.MoreDataInList() {
+		return _s.ListEnd()
+	}
+	if v, err := _s.Uint64(); err != nil {
+		return err
+	} else {
+		obj.Opt = uint64(v)
+	}
+	return _s.ListEnd()
+}
+`
+	if string(code) != want {
+		t.Errorf("generateDecoder output mismatch\n--- got ---\n%s\n--- want ---\n%s", code, want)
+	}
+}
+
+// TestGenerateDecoderGoldenMultipleOptional covers a struct with two trailing
+// optional fields. Encoding {A: 5, B: 7, C: 0} drops the zero-valued C but
+// keeps B (matching generateEncoder's _haveN logic), so the decoder must
+// check MoreDataInList before every optional field, not just the first one,
+// or it fails to decode a list that legitimately ends after B.
+func TestGenerateDecoderGoldenMultipleOptional(t *testing.T) {
+	st := types.NewStruct(
+		[]*types.Var{
+			newField("A", types.Typ[types.Uint64]),
+			newField("B", types.Typ[types.Uint64]),
+			newField("C", types.Typ[types.Uint64]),
+		},
+		[]string{``, `rlp:"optional"`, `rlp:"optional"`},
+	)
+	bctx := newBuildContext(&packages.Package{})
+	code, err := bctx.generateDecoder("Test", st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `func (obj *Test) DecodeRLP(_s *rlp.Stream) error {
+	if _, err := _s.List(); err != nil {
+		return err
+	}
+	if v, err := _s.Uint64(); err != nil {
+		return err
+	} else {
+		obj.A = uint64(v)
+	}
+	if !_s.MoreDataInList() {
+		return _s.ListEnd()
+	}
+	if v, err := _s.Uint64(); err != nil {
+		return err
+	} else {
+		obj.B = uint64(v)
+	}
+	if !_s.MoreDataInList() {
+		return _s.ListEnd()
+	}
+	if v, err := _s.Uint64(); err != nil {
+		return err
+	} else {
+		obj.C = uint64(v)
+	}
+	return _s.ListEnd()
+}
+`
+	if string(code) != want {
+		t.Errorf("generateDecoder output mismatch\n--- got ---\n%s\n--- want ---\n%s", code, want)
+	}
+}
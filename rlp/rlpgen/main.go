@@ -0,0 +1,99 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Command rlpgen generates EncodeRLP/DecodeRLP implementations for the
+// given type. The generated methods avoid the reflection overhead of the
+// default codec in package rlp, which matters on hot paths such as block
+// and transaction serialization.
+//
+// Usage:
+//
+//	rlpgen -type path/to/package.TypeName -out outfile.go
+//	rlpgen -type path/to/package.TypeName -decoder -out outfile.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func main() {
+	var (
+		pkgPattern = flag.String("type", "", "package and type, e.g. github.com/foo/bar.Baz")
+		output     = flag.String("out", "", "output file name (default stdout)")
+		decoder    = flag.Bool("decoder", false, "also generate DecodeRLP (default: only EncodeRLP)")
+	)
+	flag.Parse()
+
+	pkgName, typeName, err := splitPackageType(*pkgPattern)
+	if err != nil {
+		fatal(err)
+	}
+	pkg, err := loadPackage(pkgName)
+	if err != nil {
+		fatal(err)
+	}
+	typ := pkg.Types.Scope().Lookup(typeName)
+	if typ == nil {
+		fatal(fmt.Errorf("no such type %q in %s", typeName, pkgName))
+	}
+
+	code, err := generate(pkg, typ, *decoder)
+	if err != nil {
+		fatal(err)
+	}
+	if *output == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := os.WriteFile(*output, code, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+// splitPackageType splits a "path/to/package.TypeName" argument.
+func splitPackageType(pattern string) (pkg, typ string, err error) {
+	idx := strings.LastIndexByte(pattern, '.')
+	if idx == -1 {
+		return "", "", fmt.Errorf("-type must be of the form path/to/package.TypeName")
+	}
+	return pattern[:idx], pattern[idx+1:], nil
+}
+
+func loadPackage(name string) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo |
+			packages.NeedImports | packages.NeedDeps | packages.NeedSyntax |
+			packages.NeedTypesSizes,
+	}
+	pkgs, err := packages.Load(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no such package %q", name)
+	}
+	return pkgs[0], nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
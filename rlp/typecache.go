@@ -17,34 +17,44 @@
 package rlp
 
 import (
-	"fmt"
 	"reflect"
-	"strings"
 	"sync"
-)
+	"sync/atomic"
 
-var (
-	typeCacheMutex sync.RWMutex
-	typeCache      = make(map[typekey]*typeinfo)
+	"github.com/MatrixAINetwork/go-matrix/rlp/internal/rlpstruct"
 )
 
-type typeinfo struct {
-	decoder
-	writer
+// typeCache holds the known typeinfo structs, indexed by typekey. Lookups
+// read the cur map with a single atomic load and no locking. Insertions are
+// serialized by mu: the writer copies cur into a new map, adds the entry,
+// and publishes it back into cur with a single atomic store.
+var theTC = newTypeCache()
+
+type typeCache struct {
+	cur atomic.Value
+
+	// This lock synchronizes writers.
+	mu   sync.Mutex
+	next map[typekey]*typeinfo
 }
 
-// represents struct tags
-type tags struct {
-	// rlp:"nil" controls whether empty input results in a nil pointer.
-	nilOK bool
-	// rlp:"tail" controls whether this field swallows additional list
-	// elements. It can only be set for the last field, which must be
-	// of slice type.
-	tail bool
-	// rlp:"-" ignores fields.
-	ignored bool
+func newTypeCache() *typeCache {
+	c := new(typeCache)
+	c.cur.Store(make(map[typekey]*typeinfo))
+	return c
 }
 
+type typeinfo struct {
+	decoder    decoder
+	decoderErr error // error from makeDecoder
+	writer     writer
+	writerErr  error // error from makeWriter
+}
+
+// tags is an alias for the tag representation shared with the rlpgen code
+// generator via the rlpstruct package.
+type tags = rlpstruct.Tags
+
 type typekey struct {
 	reflect.Type
 	// the key must include the struct tags because they
@@ -56,99 +66,130 @@ type decoder func(*Stream, reflect.Value) error
 
 type writer func(reflect.Value, *encbuf) error
 
-func cachedTypeInfo(typ reflect.Type, tags tags) (*typeinfo, error) {
-	typeCacheMutex.RLock()
-	info := typeCache[typekey{typ, tags}]
-	typeCacheMutex.RUnlock()
-	if info != nil {
-		return info, nil
+// cachedTypeInfo never fails: a type that can't be encoded or decoded still
+// gets a *typeinfo, with the failure stashed in decoderErr/writerErr (see
+// genTypeInfo), so there is no separate generation error for callers to
+// handle here.
+func cachedTypeInfo(typ reflect.Type, tags tags) *typeinfo {
+	cur := theTC.cur.Load().(map[typekey]*typeinfo)
+	if info := cur[typekey{typ, tags}]; info != nil {
+		return info
 	}
-	// not in the cache, need to generate info for this type.
-	typeCacheMutex.Lock()
-	defer typeCacheMutex.Unlock()
-	return cachedTypeInfo1(typ, tags)
+	// Not in the cache, need to generate info for this type.
+	return theTC.generate(typ, tags)
 }
 
-func cachedTypeInfo1(typ reflect.Type, tags tags) (*typeinfo, error) {
+func (c *typeCache) generate(typ reflect.Type, tags tags) *typeinfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cur := c.cur.Load().(map[typekey]*typeinfo)
+	if info := cur[typekey{typ, tags}]; info != nil {
+		return info
+	}
+
+	// Copy cur to next.
+	c.next = make(map[typekey]*typeinfo, len(cur)+1)
+	for k, v := range cur {
+		c.next[k] = v
+	}
+
+	// Generate.
+	info := c.infoWhileGenerating(typ, tags)
+	// next -> cur
+	c.cur.Store(c.next)
+	c.next = nil
+	return info
+}
+
+func (c *typeCache) infoWhileGenerating(typ reflect.Type, tags tags) *typeinfo {
 	key := typekey{typ, tags}
-	info := typeCache[key]
-	if info != nil {
-		// another goroutine got the write lock first
-		return info, nil
+	if info := c.next[key]; info != nil {
+		return info
 	}
-	// put a dummmy value into the cache before generating.
-	// if the generator tries to lookup itself, it will get
+	// Put a dummy value into the cache before generating.
+	// If the generator tries to lookup itself, it will get
 	// the dummy value and won't call itself recursively.
-	typeCache[key] = new(typeinfo)
-	info, err := genTypeInfo(typ, tags)
-	if err != nil {
-		// remove the dummy value if the generator fails
-		delete(typeCache, key)
-		return nil, err
-	}
-	*typeCache[key] = *info
-	return typeCache[key], err
+	info := new(typeinfo)
+	c.next[key] = info
+	*info = *genTypeInfo(typ, tags)
+	return info
 }
 
 type field struct {
-	index int
-	info  *typeinfo
+	index    int
+	info     *typeinfo
+	optional bool
 }
 
 func structFields(typ reflect.Type) (fields []field, err error) {
+	// Convert fields to rlpstruct.Field and call the generic processor.
+	var allStructFields []rlpstruct.Field
 	for i := 0; i < typ.NumField(); i++ {
-		if f := typ.Field(i); f.PkgPath == "" { // exported
-			tags, err := parseStructTag(typ, i)
-			if err != nil {
-				return nil, err
-			}
-			if tags.ignored {
-				continue
-			}
-			info, err := cachedTypeInfo1(f.Type, tags)
-			if err != nil {
-				return nil, err
-			}
-			fields = append(fields, field{i, info})
+		rf := typ.Field(i)
+		allStructFields = append(allStructFields, rlpstruct.Field{
+			Name:     rf.Name,
+			Index:    i,
+			Exported: rf.PkgPath == "",
+			Tag:      string(rf.Tag),
+			Type:     *rtypeToStructType(rf.Type, nil),
+		})
+	}
+	structFieldsList, ts, err := rlpstruct.ProcessFields(allStructFields)
+	if err != nil {
+		if tagErr, ok := err.(*rlpstruct.TagError); ok {
+			tagErr.StructType = typ.String()
+			return nil, tagErr
 		}
+		return nil, err
+	}
+	for i, sf := range structFieldsList {
+		info := theTC.infoWhileGenerating(typ.Field(sf.Index).Type, ts[i])
+		fields = append(fields, field{sf.Index, info, ts[i].Optional})
 	}
 	return fields, nil
 }
 
-func parseStructTag(typ reflect.Type, fi int) (tags, error) {
-	f := typ.Field(fi)
-	var ts tags
-	for _, t := range strings.Split(f.Tag.Get("rlp"), ",") {
-		switch t = strings.TrimSpace(t); t {
-		case "":
-		case "-":
-			ts.ignored = true
-		case "nil":
-			ts.nilOK = true
-		case "tail":
-			ts.tail = true
-			if fi != typ.NumField()-1 {
-				return ts, fmt.Errorf(`rlp: invalid struct tag "tail" for %v.%s (must be on last field)`, typ, f.Name)
-			}
-			if f.Type.Kind() != reflect.Slice {
-				return ts, fmt.Errorf(`rlp: invalid struct tag "tail" for %v.%s (field type is not slice)`, typ, f.Name)
-			}
-		default:
-			return ts, fmt.Errorf("rlp: unknown struct tag %q on %v.%s", t, typ, f.Name)
-		}
+// rtypeToStructType converts a reflect.Type into rlpstruct.Type.
+func rtypeToStructType(typ reflect.Type, rec map[reflect.Type]*rlpstruct.Type) *rlpstruct.Type {
+	k := typ.Kind()
+	if k == reflect.Invalid {
+		panic("invalid kind")
 	}
-	return ts, nil
-}
 
-func genTypeInfo(typ reflect.Type, tags tags) (info *typeinfo, err error) {
-	info = new(typeinfo)
-	if info.decoder, err = makeDecoder(typ, tags); err != nil {
-		return nil, err
+	if rec == nil {
+		rec = make(map[reflect.Type]*rlpstruct.Type)
 	}
-	if info.writer, err = makeWriter(typ, tags); err != nil {
-		return nil, err
+	t, ok := rec[typ]
+	if ok {
+		return t // short-circuit for recursive types
+	}
+
+	t = &rlpstruct.Type{
+		Name:      typ.Name(),
+		Kind:      k,
+		IsEncoder: typ.Implements(encoderInterface),
+		IsDecoder: typ.Implements(decoderInterface),
+		PkgPath:   typ.PkgPath(),
 	}
-	return info, nil
+	rec[typ] = t
+	if k == reflect.Array || k == reflect.Slice || k == reflect.Ptr {
+		t.Elem = rtypeToStructType(typ.Elem(), rec)
+	}
+	return t
+}
+
+// genTypeInfo always returns a populated *typeinfo, even when generation of
+// either direction fails: the failure is stashed in decoderErr/writerErr so
+// that it is cached and returned on every subsequent encode/decode of typ,
+// instead of re-running makeDecoder/makeWriter (and re-allocating the same
+// error) on every call. A type may well have a working writer but no
+// decoder, or vice versa; each direction is judged independently.
+func genTypeInfo(typ reflect.Type, tags tags) *typeinfo {
+	info := new(typeinfo)
+	info.decoder, info.decoderErr = makeDecoder(typ, tags)
+	info.writer, info.writerErr = makeWriter(typ, tags)
+	return info
 }
 
 func isUint(k reflect.Kind) bool {
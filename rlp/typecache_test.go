@@ -0,0 +1,101 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rlp
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recursiveListElem is self-referential through a nil-able pointer, the
+// shape that exercises infoWhileGenerating's placeholder trick: building the
+// writer/decoder for recursiveListElem requires the writer/decoder for
+// recursiveListElem itself.
+type recursiveListElem struct {
+	Value uint64
+	Next  *recursiveListElem `rlp:"nil"`
+}
+
+// TestTypeCacheRecursiveType checks that generating codec info for a
+// self-referential type terminates instead of recursing forever, and that
+// the resulting typeinfo is actually usable once generation has completed.
+func TestTypeCacheRecursiveType(t *testing.T) {
+	typ := reflect.TypeOf(recursiveListElem{})
+	info := cachedTypeInfo(typ, tags{})
+	if info.writerErr != nil {
+		t.Fatalf("writerErr = %v, want nil", info.writerErr)
+	}
+	if info.decoderErr != nil {
+		t.Fatalf("decoderErr = %v, want nil", info.decoderErr)
+	}
+
+	list := &recursiveListElem{Value: 1, Next: &recursiveListElem{Value: 2, Next: &recursiveListElem{Value: 3}}}
+	enc, err := EncodeToBytes(list)
+	if err != nil {
+		t.Fatalf("EncodeToBytes: %v", err)
+	}
+	var got recursiveListElem
+	if err := DecodeBytes(enc, &got); err != nil {
+		t.Fatalf("DecodeBytes: %v", err)
+	}
+	cur := &got
+	for i, want := range []uint64{1, 2, 3} {
+		if cur.Value != want {
+			t.Errorf("element %d: Value = %d, want %d", i, cur.Value, want)
+		}
+		if i < 2 {
+			if cur.Next == nil {
+				t.Fatalf("element %d: Next = nil, want non-nil", i)
+			}
+			cur = cur.Next
+		}
+	}
+	if cur.Next != nil {
+		t.Errorf("last element: Next = %v, want nil", cur.Next)
+	}
+}
+
+// TestTypeCacheConcurrentAccess generates codec info for several distinct
+// types from many goroutines at once. It is meant to be run with -race: the
+// typeCache's cur map must only ever be read via the atomic.Value, and
+// writes must be serialized through mu, or this test will report a race.
+func TestTypeCacheConcurrentAccess(t *testing.T) {
+	type a struct{ X uint64 }
+	type b struct{ X string }
+	type c struct{ X []byte }
+	types := []reflect.Type{
+		reflect.TypeOf(a{}),
+		reflect.TypeOf(b{}),
+		reflect.TypeOf(c{}),
+		reflect.TypeOf(recursiveListElem{}),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			typ := types[i%len(types)]
+			info := cachedTypeInfo(typ, tags{})
+			if info == nil {
+				t.Errorf("cachedTypeInfo(%v) = nil", typ)
+			}
+		}(i)
+	}
+	wg.Wait()
+}